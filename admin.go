@@ -0,0 +1,179 @@
+// admin.go
+
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// StartAdminServer starts the HTTP admin/metrics server. It blocks until the server
+// stops, so callers run it in its own goroutine, similar to StartTemperatureMonitoring.
+// If Config.AdminBindAddress is empty, the admin server is disabled.
+func (hm *HeatingManager) StartAdminServer() {
+	bindAddress := hm.Cfg().AdminBindAddress
+	if bindAddress == "" {
+		log.Println("Admin server disabled: no adminBindAddress configured")
+		return
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", hm.handleMetrics)
+	mux.HandleFunc("/status", hm.authenticated(hm.handleStatus))
+	mux.HandleFunc("/heating/on", hm.authenticated(hm.handleHeatingOn))
+	mux.HandleFunc("/heating/off", hm.authenticated(hm.handleHeatingOff))
+	mux.HandleFunc("/weekly-check/run-now", hm.authenticated(hm.handleWeeklyCheckRunNow))
+	mux.HandleFunc("/config", hm.authenticated(hm.handleConfig))
+
+	hm.adminServer = &http.Server{
+		Addr:    bindAddress,
+		Handler: mux,
+	}
+
+	log.Printf("Admin server listening on %s", bindAddress)
+	if err := hm.adminServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Printf("Admin server stopped: %v", err)
+	}
+}
+
+// StopAdminServer gracefully shuts down the admin server, if it is running.
+func (hm *HeatingManager) StopAdminServer(ctx context.Context) error {
+	if hm.adminServer == nil {
+		return nil
+	}
+	return hm.adminServer.Shutdown(ctx)
+}
+
+// authenticated wraps a handler so it requires a bearer token matching Config.AdminAuthToken.
+// An empty AdminAuthToken disables auth entirely, which is only recommended behind a trusted network.
+func (hm *HeatingManager) authenticated(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token := hm.Cfg().AdminAuthToken; token != "" {
+			const prefix = "Bearer "
+			header := r.Header.Get("Authorization")
+			if !strings.HasPrefix(header, prefix) || subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(header, prefix)), []byte(token)) != 1 {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+// handleMetrics serves the Prometheus text-exposition format. It is intentionally
+// unauthenticated so it can be scraped the same way as any other Prometheus target.
+func (hm *HeatingManager) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	hm.Metrics.WritePrometheus(w)
+}
+
+// statusResponse is the JSON payload returned by GET /status.
+type statusResponse struct {
+	CurrentTemperature   float64   `json:"currentTemperature"`
+	TemperatureThreshold float64   `json:"temperatureThreshold"`
+	TemperatureExceeded  bool      `json:"temperatureExceeded"`
+	HeatPumpOn           bool      `json:"heatPumpOn"`
+	HeatingCommandedOn   bool      `json:"heatingCommandedOn"`
+	PVSurplusWatts       float64   `json:"pvSurplusWatts"`
+	LastCheck            time.Time `json:"lastCheck"`
+}
+
+func (hm *HeatingManager) handleStatus(w http.ResponseWriter, r *http.Request) {
+	snapshot := hm.Metrics.Snapshot()
+	writeJSON(w, http.StatusOK, statusResponse{
+		CurrentTemperature:   snapshot.CurrentTemperature,
+		TemperatureThreshold: snapshot.TemperatureThreshold,
+		TemperatureExceeded:  hm.isTemperatureExceeded(),
+		HeatPumpOn:           snapshot.HeatPumpOn,
+		HeatingCommandedOn:   hm.isHeatingCommandedOn(),
+		PVSurplusWatts:       snapshot.PVSurplusWatts,
+		LastCheck:            snapshot.LastCheck,
+	})
+}
+
+func (hm *HeatingManager) handleHeatingOn(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := hm.commandHeating(true); err != nil {
+		writeJSON(w, http.StatusBadGateway, errorResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, okResponse{OK: true})
+}
+
+func (hm *HeatingManager) handleHeatingOff(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := hm.commandHeating(false); err != nil {
+		writeJSON(w, http.StatusBadGateway, errorResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, okResponse{OK: true})
+}
+
+func (hm *HeatingManager) handleWeeklyCheckRunNow(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	go hm.weeklyCheck()
+	writeJSON(w, http.StatusAccepted, okResponse{OK: true})
+}
+
+// configResponse exposes only the Config fields listed below over the admin API. The
+// password and every other token or secret are among the fields it leaves out.
+type configResponse struct {
+	SolarManagerURL      string  `json:"solarManagerURL"`
+	SolarManagerSensorID string  `json:"solarManagerSensorID"`
+	TemperatureThreshold float64 `json:"temperatureThreshold"`
+	TemperatureTurnOff   float64 `json:"temperatureTurnOff"`
+	CheckInterval        int     `json:"checkInterval"`
+	WeeklyCheckCron      string  `json:"weeklyCheckCron"`
+	Username             string  `json:"username"`
+	HeatPumpID           string  `json:"heatPumpID"`
+	HeatPumpControlURL   string  `json:"heatPumpControlURL"`
+	AdminBindAddress     string  `json:"adminBindAddress"`
+	MinSurplusWatts      float64 `json:"minSurplusWatts"`
+}
+
+func (hm *HeatingManager) handleConfig(w http.ResponseWriter, r *http.Request) {
+	cfg := hm.Cfg()
+	writeJSON(w, http.StatusOK, configResponse{
+		SolarManagerURL:      cfg.SolarManagerURL,
+		SolarManagerSensorID: cfg.SolarManagerSensorID,
+		TemperatureThreshold: cfg.TemperatureThreshold,
+		TemperatureTurnOff:   cfg.TemperatureTurnOff,
+		CheckInterval:        cfg.CheckInterval,
+		WeeklyCheckCron:      cfg.WeeklyCheckCron,
+		Username:             cfg.Username,
+		HeatPumpID:           cfg.HeatPumpID,
+		HeatPumpControlURL:   cfg.HeatPumpControlURL,
+		AdminBindAddress:     cfg.AdminBindAddress,
+		MinSurplusWatts:      cfg.MinSurplusWatts,
+	})
+}
+
+type okResponse struct {
+	OK bool `json:"ok"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("failed to write JSON response: %v", err)
+	}
+}
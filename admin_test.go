@@ -0,0 +1,84 @@
+// admin_test.go
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newTestHeatingManager() *HeatingManager {
+	return &HeatingManager{
+		cfg: Config{
+			TemperatureThreshold: 60,
+			AdminAuthToken:       "secret-token",
+		},
+		Metrics: NewMetrics(),
+	}
+}
+
+func TestAdminEndpointsRequireAuth(t *testing.T) {
+	hm := newTestHeatingManager()
+
+	req := httptest.NewRequest(http.MethodGet, "/status", nil)
+	w := httptest.NewRecorder()
+	hm.authenticated(hm.handleStatus)(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without a token, got %d", w.Code)
+	}
+}
+
+func TestAdminEndpointsAcceptValidToken(t *testing.T) {
+	hm := newTestHeatingManager()
+	hm.Metrics.SetCurrentTemperature(55.5)
+
+	req := httptest.NewRequest(http.MethodGet, "/status", nil)
+	req.Header.Set("Authorization", "Bearer secret-token")
+	w := httptest.NewRecorder()
+	hm.authenticated(hm.handleStatus)(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200 with a valid token, got %d", w.Code)
+	}
+	if !strings.Contains(w.Body.String(), `"currentTemperature":55.5`) {
+		t.Fatalf("expected status body to contain current temperature, got %q", w.Body.String())
+	}
+}
+
+func TestHandleMetrics(t *testing.T) {
+	hm := newTestHeatingManager()
+	hm.Metrics.SetCurrentTemperature(42)
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	w := httptest.NewRecorder()
+	hm.handleMetrics(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", w.Code)
+	}
+	body := w.Body.String()
+	if !strings.Contains(body, "pv_heating_current_water_temp_celsius 42") {
+		t.Fatalf("expected metrics body to contain current temperature, got %q", body)
+	}
+	if !strings.Contains(body, "# TYPE pv_heating_weekly_check_total counter") {
+		t.Fatalf("expected metrics body to contain weekly check counter, got %q", body)
+	}
+}
+
+func TestHandleConfigRedactsPassword(t *testing.T) {
+	hm := newTestHeatingManager()
+	cfg := hm.Cfg()
+	cfg.Password = "super-secret"
+	hm.setCfg(cfg)
+
+	req := httptest.NewRequest(http.MethodGet, "/config", nil)
+	w := httptest.NewRecorder()
+	hm.handleConfig(w, req)
+
+	if strings.Contains(w.Body.String(), "super-secret") {
+		t.Fatalf("expected config response to omit the password, got %q", w.Body.String())
+	}
+}
@@ -5,40 +5,79 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
 	"time"
 )
 
-// HeatingManager represents the main application struct.
-type HeatingManager struct {
-	Config      Config
-	Token       string
-	TokenExpiry time.Time
-}
+// defaultTokenRefreshThreshold is how long before expiry the token is proactively
+// refreshed when Config.TokenRefreshThreshold is not set.
+const defaultTokenRefreshThreshold = 5 * time.Minute
 
-// getAuthToken gets or refreshes the authentication token as necessary.
+// getAuthToken gets or refreshes the authentication token as necessary and returns
+// the token to use. Callers must use the returned value rather than reading hm.Token
+// themselves afterward: once refreshMu is released, a concurrent refresh from another
+// goroutine could have already replaced it.
 //
 // This function first checks if a token is present, and if not, performs a login.
-// If a token is present, it checks if it has expired. If it has, the function performs a token refresh.
-// If a valid token is present, the function does nothing.
+// If a token is present, it proactively refreshes it once it is within
+// Config.TokenRefreshThreshold of expiry, rather than waiting for it to expire.
+// If a valid, non-expiring-soon token is present, the function does nothing.
+//
+// getAuthToken is safe for concurrent use: refreshMu serializes callers so that
+// two goroutines racing to refresh an expiring token only trigger one refresh.
 //
 // Returns:
 // - error: If there was an error during the process of obtaining or refreshing the token.
-func (hm *HeatingManager) getAuthToken() error {
+// - string: The token to use; empty when an error is returned.
+func (hm *HeatingManager) getAuthToken() (string, error) {
+	hm.refreshMu.Lock()
+	defer hm.refreshMu.Unlock()
+
 	// Check if a token is present
 	if hm.Token == "" {
 		// If no token is present, perform a login
-		return hm.login()
+		if err := hm.login(); err != nil {
+			return "", err
+		}
+		return hm.Token, nil
 	}
 
-	// Check if the token has expired
-	if time.Now().After(hm.TokenExpiry) {
-		// If the token has expired, perform a token refresh
-		return hm.refreshToken()
+	// Check if the token has expired or is about to
+	if hm.tokenNeedsRefresh() {
+		if err := hm.refreshToken(); err != nil {
+			return "", err
+		}
 	}
 
 	// If a valid token is present, do nothing
-	return nil
+	return hm.Token, nil
+}
+
+// tokenNeedsRefresh reports whether the current token has already expired, or will
+// expire within Config.TokenRefreshThreshold (5 minutes by default).
+func (hm *HeatingManager) tokenNeedsRefresh() bool {
+	threshold := defaultTokenRefreshThreshold
+	if minutes := hm.Cfg().TokenRefreshThreshold; minutes > 0 {
+		threshold = time.Duration(minutes) * time.Minute
+	}
+	return time.Now().Add(threshold).After(hm.TokenExpiry)
+}
+
+// persistToken saves the current token state to the token store. Failure to persist
+// is logged but not returned, since an unpersisted token is still usable until restart.
+func (hm *HeatingManager) persistToken() {
+	if hm.TokenStore == nil {
+		return
+	}
+	data := TokenData{
+		AccessToken:  hm.Token,
+		RefreshToken: hm.RefreshToken,
+		TokenExpiry:  hm.TokenExpiry,
+	}
+	if err := hm.TokenStore.Save(data); err != nil {
+		log.Printf("Failed to persist auth token: %v", err)
+	}
 }
 
 // login performs a login to obtain a new authentication token.
@@ -50,9 +89,10 @@ func (hm *HeatingManager) getAuthToken() error {
 // - error: If there was an error during the login process.
 func (hm *HeatingManager) login() error {
 	url := "https://cloud.solar-manager.ch/v1/oauth/login"
+	cfg := hm.Cfg()
 	credentials := map[string]string{
-		"email":    hm.Config.Username,
-		"password": hm.Config.Password,
+		"email":    cfg.Username,
+		"password": cfg.Password,
 	}
 	credentialsJSON, err := json.Marshal(credentials)
 	if err != nil {
@@ -71,16 +111,19 @@ func (hm *HeatingManager) login() error {
 	}
 
 	var result struct {
-		AccessToken string `json:"accessToken"`
-		ExpiresIn   int    `json:"expiresIn"` // Duration until the token expires in seconds
+		AccessToken  string `json:"accessToken"`
+		RefreshToken string `json:"refreshToken"`
+		ExpiresIn    int    `json:"expiresIn"` // Duration until the token expires in seconds
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return fmt.Errorf("error decoding auth response: %v", err)
 	}
 
 	hm.Token = result.AccessToken
+	hm.RefreshToken = result.RefreshToken
 	// Set the expiry date of the token based on the current time plus the token's duration
 	hm.TokenExpiry = time.Now().Add(time.Duration(result.ExpiresIn) * time.Second)
+	hm.persistToken()
 
 	return nil
 }
@@ -93,6 +136,15 @@ func (hm *HeatingManager) login() error {
 // Returns:
 // - error: If there was an error during the refresh process.
 func (hm *HeatingManager) refreshToken() error {
+	start := time.Now()
+	err := hm.doRefreshToken()
+	hm.Metrics.ObserveRequestLatency("solar_manager_oauth_refresh", time.Since(start))
+	hm.Metrics.IncTokenRefresh(err == nil)
+	return err
+}
+
+// doRefreshToken performs the actual refresh request; refreshToken wraps it with metrics.
+func (hm *HeatingManager) doRefreshToken() error {
 	url := "https://cloud.solar-manager.ch/v1/oauth/refresh"
 	req, err := http.NewRequest("POST", url, nil)
 	if err != nil {
@@ -113,15 +165,20 @@ func (hm *HeatingManager) refreshToken() error {
 	}
 
 	var result struct {
-		AccessToken string `json:"accessToken"`
-		ExpiresIn   int    `json:"expiresIn"` // Duration until the token expires in seconds
+		AccessToken  string `json:"accessToken"`
+		RefreshToken string `json:"refreshToken"`
+		ExpiresIn    int    `json:"expiresIn"` // Duration until the token expires in seconds
 	}
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return fmt.Errorf("error decoding refresh response: %v", err)
 	}
 
 	hm.Token = result.AccessToken
+	if result.RefreshToken != "" {
+		hm.RefreshToken = result.RefreshToken
+	}
 	hm.TokenExpiry = time.Now().Add(time.Duration(result.ExpiresIn) * time.Second)
+	hm.persistToken()
 
 	return nil
 }
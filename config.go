@@ -6,6 +6,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"reflect"
+	"strconv"
 )
 
 // Config contains the configuration settings for the application.
@@ -20,8 +22,14 @@ type Config struct {
 	TemperatureTurnOff float64 `json:"temperatureTurnOff"`
 	// CheckInterval is the interval in minutes at which the temperature should be checked.
 	CheckInterval int `json:"checkInterval"`
-	// WeeklyCheckInterval is the interval in minutes at which a weekly check should be performed.
-	WeeklyCheckInterval int `json:"weeklyCheckInterval"`
+	// WeeklyCheckCron is a 5-field cron expression (with an optional leading seconds field)
+	// controlling when the weekly Legionella check runs, e.g. "0 3 * * 1" for Mondays at 03:00.
+	WeeklyCheckCron string `json:"weeklyCheckCron"`
+	// WeeklyCheckJitter is the maximum random delay, in seconds, added after each scheduled
+	// fire time to spread load across many deployed devices.
+	WeeklyCheckJitter int `json:"weeklyCheckJitter"`
+	// HeatingOnDuration is how long the heating stays on during the weekly check, in minutes.
+	HeatingOnDuration int `json:"heatingOnDuration"`
 	// Username is the username for authentication with the Solar Manager API.
 	Username string `json:"username"`
 	// Password is the password for authentication with the Solar Manager API.
@@ -30,9 +38,54 @@ type Config struct {
 	HeatPumpID string `json:"heatPumpID"`
 	// HeatPumpControlURL is the URL of the heat pump control API.
 	HeatPumpControlURL string `json:"heatPumpControlURL"`
+	// AdminBindAddress is the address (host:port) the admin/metrics HTTP server listens on. Leave empty to disable it.
+	AdminBindAddress string `json:"adminBindAddress"`
+	// AdminAuthToken is the bearer token required to access the admin endpoints (not required for /metrics).
+	AdminAuthToken string `json:"adminAuthToken"`
+	// TokenCacheFile is the path to the encrypted file used to persist the auth token across restarts.
+	TokenCacheFile string `json:"tokenCacheFile"`
+	// TokenEncryptionSecret is used to derive the key that encrypts the token cache at rest.
+	TokenEncryptionSecret string `json:"tokenEncryptionSecret"`
+	// TokenRefreshThreshold is how long before expiry, in minutes, the token is proactively refreshed.
+	TokenRefreshThreshold int `json:"tokenRefreshThreshold"`
+
+	// PVSurplusURL is the Solar Manager endpoint reporting available PV surplus, in watts.
+	PVSurplusURL string `json:"pvSurplusURL"`
+	// MinSurplusWatts is the minimum PV surplus required before heating is opportunistically turned on.
+	MinSurplusWatts float64 `json:"minSurplusWatts"`
+	// HeatingOnDebounceChecks is how many consecutive monitoring checks must show the temperature
+	// below TemperatureThreshold and surplus above MinSurplusWatts before heating is turned on.
+	HeatingOnDebounceChecks int `json:"heatingOnDebounceChecks"`
+	// HeatingOffDebounceChecks is how many consecutive checks with surplus at or below MinSurplusWatts
+	// before heating is turned off. A temperature at or above TemperatureTurnOff turns it off
+	// immediately, without waiting for this debounce.
+	HeatingOffDebounceChecks int `json:"heatingOffDebounceChecks"`
+
+	// Driver selects the heat pump backend: "solarmanager" (default), "mqtt", or "homeassistant".
+	Driver string `json:"driver"`
+
+	// MQTTBrokerAddress is the host:port of the MQTT broker used by the mqtt driver.
+	MQTTBrokerAddress string `json:"mqttBrokerAddress"`
+	// MQTTClientID is the client ID the mqtt driver identifies itself with.
+	MQTTClientID string `json:"mqttClientID"`
+	// MQTTChargingTopic is the topic the mqtt driver publishes charging-mode commands to.
+	MQTTChargingTopic string `json:"mqttChargingTopic"`
+	// MQTTTemperatureTopic is the topic the mqtt driver subscribes to for temperature readings.
+	MQTTTemperatureTopic string `json:"mqttTemperatureTopic"`
+
+	// HomeAssistantURL is the base URL of the Home Assistant instance used by the homeassistant driver.
+	HomeAssistantURL string `json:"homeAssistantURL"`
+	// HomeAssistantToken is the long-lived access token used to authenticate with Home Assistant.
+	HomeAssistantToken string `json:"homeAssistantToken"`
+	// HomeAssistantClimateEntityID is the climate entity controlled by the homeassistant driver.
+	HomeAssistantClimateEntityID string `json:"homeAssistantClimateEntityID"`
+	// HomeAssistantTemperatureEntityID is the sensor entity read for the water temperature.
+	// If empty, the climate entity's own state is used instead.
+	HomeAssistantTemperatureEntityID string `json:"homeAssistantTemperatureEntityID"`
 }
 
-// loadConfig loads the configuration from the config.json file.
+// loadConfig loads the configuration from the config.json file, then layers any
+// PVHM_* environment variable overrides on top of it.
 // It returns the configuration and an error if any.
 func loadConfig() (Config, error) {
 	var config Config
@@ -47,5 +100,155 @@ func loadConfig() (Config, error) {
 		return config, fmt.Errorf("failed to parse config file: %v", err)
 	}
 
+	config, err = applyEnvOverrides(config)
+	if err != nil {
+		return config, fmt.Errorf("failed to apply environment overrides: %v", err)
+	}
+
 	return config, nil
 }
+
+// validateConfig rejects configurations that would leave the heating manager in an
+// inconsistent state. It is run on every load, including hot-reloads, so a broken
+// config.json edit or environment override never takes effect.
+func validateConfig(cfg Config) error {
+	if cfg.TemperatureTurnOff >= cfg.TemperatureThreshold {
+		return fmt.Errorf("temperatureTurnOff (%.1f) must be lower than temperatureThreshold (%.1f)", cfg.TemperatureTurnOff, cfg.TemperatureThreshold)
+	}
+	if cfg.WeeklyCheckCron != "" {
+		if _, err := ParseCron(cfg.WeeklyCheckCron); err != nil {
+			return fmt.Errorf("invalid weeklyCheckCron: %w", err)
+		}
+	}
+	return nil
+}
+
+// sensitiveConfigFields lists Config fields whose values are masked when logging a
+// diff between configurations, so reload log lines never leak credentials.
+var sensitiveConfigFields = map[string]bool{
+	"Password":              true,
+	"AdminAuthToken":        true,
+	"TokenEncryptionSecret": true,
+	"HomeAssistantToken":    true,
+}
+
+// diffConfig returns a human-readable list of fields that changed between old and
+// new, for logging when a hot-reload takes effect. Sensitive fields are masked.
+func diffConfig(old, new Config) []string {
+	var changes []string
+
+	oldVal := reflect.ValueOf(old)
+	newVal := reflect.ValueOf(new)
+	t := oldVal.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Name
+		oldField := oldVal.Field(i).Interface()
+		newField := newVal.Field(i).Interface()
+		if oldField == newField {
+			continue
+		}
+
+		if sensitiveConfigFields[name] {
+			changes = append(changes, fmt.Sprintf("%s changed", name))
+			continue
+		}
+		changes = append(changes, fmt.Sprintf("%s: %v -> %v", name, oldField, newField))
+	}
+
+	return changes
+}
+
+// applyEnvOverrides layers PVHM_<FIELD> environment variables on top of a config
+// already loaded from config.json, letting deployments override individual settings
+// (e.g. secrets injected by an orchestrator) without editing the file on disk.
+func applyEnvOverrides(cfg Config) (Config, error) {
+	cfg.SolarManagerURL = envString("PVHM_SOLAR_MANAGER_URL", cfg.SolarManagerURL)
+	cfg.SolarManagerSensorID = envString("PVHM_SOLAR_MANAGER_SENSOR_ID", cfg.SolarManagerSensorID)
+	cfg.Username = envString("PVHM_USERNAME", cfg.Username)
+	cfg.Password = envString("PVHM_PASSWORD", cfg.Password)
+	cfg.HeatPumpID = envString("PVHM_HEAT_PUMP_ID", cfg.HeatPumpID)
+	cfg.HeatPumpControlURL = envString("PVHM_HEAT_PUMP_CONTROL_URL", cfg.HeatPumpControlURL)
+	cfg.WeeklyCheckCron = envString("PVHM_WEEKLY_CHECK_CRON", cfg.WeeklyCheckCron)
+	cfg.PVSurplusURL = envString("PVHM_PV_SURPLUS_URL", cfg.PVSurplusURL)
+	cfg.AdminBindAddress = envString("PVHM_ADMIN_BIND_ADDRESS", cfg.AdminBindAddress)
+	cfg.AdminAuthToken = envString("PVHM_ADMIN_AUTH_TOKEN", cfg.AdminAuthToken)
+	cfg.TokenCacheFile = envString("PVHM_TOKEN_CACHE_FILE", cfg.TokenCacheFile)
+	cfg.TokenEncryptionSecret = envString("PVHM_TOKEN_ENCRYPTION_SECRET", cfg.TokenEncryptionSecret)
+	cfg.Driver = envString("PVHM_DRIVER", cfg.Driver)
+	cfg.MQTTBrokerAddress = envString("PVHM_MQTT_BROKER_ADDRESS", cfg.MQTTBrokerAddress)
+	cfg.MQTTClientID = envString("PVHM_MQTT_CLIENT_ID", cfg.MQTTClientID)
+	cfg.MQTTChargingTopic = envString("PVHM_MQTT_CHARGING_TOPIC", cfg.MQTTChargingTopic)
+	cfg.MQTTTemperatureTopic = envString("PVHM_MQTT_TEMPERATURE_TOPIC", cfg.MQTTTemperatureTopic)
+	cfg.HomeAssistantURL = envString("PVHM_HOME_ASSISTANT_URL", cfg.HomeAssistantURL)
+	cfg.HomeAssistantToken = envString("PVHM_HOME_ASSISTANT_TOKEN", cfg.HomeAssistantToken)
+	cfg.HomeAssistantClimateEntityID = envString("PVHM_HOME_ASSISTANT_CLIMATE_ENTITY_ID", cfg.HomeAssistantClimateEntityID)
+	cfg.HomeAssistantTemperatureEntityID = envString("PVHM_HOME_ASSISTANT_TEMPERATURE_ENTITY_ID", cfg.HomeAssistantTemperatureEntityID)
+
+	var err error
+	if cfg.TemperatureThreshold, err = envFloat("PVHM_TEMPERATURE_THRESHOLD", cfg.TemperatureThreshold); err != nil {
+		return cfg, err
+	}
+	if cfg.TemperatureTurnOff, err = envFloat("PVHM_TEMPERATURE_TURN_OFF", cfg.TemperatureTurnOff); err != nil {
+		return cfg, err
+	}
+	if cfg.MinSurplusWatts, err = envFloat("PVHM_MIN_SURPLUS_WATTS", cfg.MinSurplusWatts); err != nil {
+		return cfg, err
+	}
+	if cfg.HeatingOnDebounceChecks, err = envInt("PVHM_HEATING_ON_DEBOUNCE_CHECKS", cfg.HeatingOnDebounceChecks); err != nil {
+		return cfg, err
+	}
+	if cfg.HeatingOffDebounceChecks, err = envInt("PVHM_HEATING_OFF_DEBOUNCE_CHECKS", cfg.HeatingOffDebounceChecks); err != nil {
+		return cfg, err
+	}
+	if cfg.CheckInterval, err = envInt("PVHM_CHECK_INTERVAL", cfg.CheckInterval); err != nil {
+		return cfg, err
+	}
+	if cfg.WeeklyCheckJitter, err = envInt("PVHM_WEEKLY_CHECK_JITTER", cfg.WeeklyCheckJitter); err != nil {
+		return cfg, err
+	}
+	if cfg.HeatingOnDuration, err = envInt("PVHM_HEATING_ON_DURATION", cfg.HeatingOnDuration); err != nil {
+		return cfg, err
+	}
+	if cfg.TokenRefreshThreshold, err = envInt("PVHM_TOKEN_REFRESH_THRESHOLD", cfg.TokenRefreshThreshold); err != nil {
+		return cfg, err
+	}
+
+	return cfg, nil
+}
+
+// envString returns the environment variable named by key, or current if it is unset.
+func envString(key, current string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+	return current
+}
+
+// envFloat returns the environment variable named by key parsed as a float64, or
+// current if it is unset.
+func envFloat(key string, current float64) (float64, error) {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return current, nil
+	}
+	parsed, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return current, fmt.Errorf("environment variable %s=%q is not a valid number: %w", key, v, err)
+	}
+	return parsed, nil
+}
+
+// envInt returns the environment variable named by key parsed as an int, or current
+// if it is unset.
+func envInt(key string, current int) (int, error) {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return current, nil
+	}
+	parsed, err := strconv.Atoi(v)
+	if err != nil {
+		return current, fmt.Errorf("environment variable %s=%q is not a valid integer: %w", key, v, err)
+	}
+	return parsed, nil
+}
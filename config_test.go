@@ -0,0 +1,96 @@
+// config_test.go
+
+package main
+
+import "testing"
+
+func TestApplyEnvOverrides(t *testing.T) {
+	t.Setenv("PVHM_TEMPERATURE_THRESHOLD", "65.5")
+	t.Setenv("PVHM_CHECK_INTERVAL", "10")
+	t.Setenv("PVHM_DRIVER", "mqtt")
+
+	cfg, err := applyEnvOverrides(Config{
+		TemperatureThreshold: 60,
+		CheckInterval:        5,
+		Driver:               "solarmanager",
+	})
+	if err != nil {
+		t.Fatalf("applyEnvOverrides returned an error: %v", err)
+	}
+
+	if cfg.TemperatureThreshold != 65.5 {
+		t.Errorf("TemperatureThreshold = %v, want 65.5", cfg.TemperatureThreshold)
+	}
+	if cfg.CheckInterval != 10 {
+		t.Errorf("CheckInterval = %v, want 10", cfg.CheckInterval)
+	}
+	if cfg.Driver != "mqtt" {
+		t.Errorf("Driver = %v, want mqtt", cfg.Driver)
+	}
+}
+
+func TestApplyEnvOverridesLeavesUnsetFieldsAlone(t *testing.T) {
+	cfg, err := applyEnvOverrides(Config{Username: "alice"})
+	if err != nil {
+		t.Fatalf("applyEnvOverrides returned an error: %v", err)
+	}
+	if cfg.Username != "alice" {
+		t.Errorf("Username = %v, want alice", cfg.Username)
+	}
+}
+
+func TestApplyEnvOverridesRejectsInvalidNumber(t *testing.T) {
+	t.Setenv("PVHM_CHECK_INTERVAL", "not-a-number")
+
+	if _, err := applyEnvOverrides(Config{}); err == nil {
+		t.Fatal("expected an error for a non-numeric PVHM_CHECK_INTERVAL")
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr bool
+	}{
+		{"valid", Config{TemperatureThreshold: 60, TemperatureTurnOff: 50}, false},
+		{"turn off above threshold", Config{TemperatureThreshold: 60, TemperatureTurnOff: 65}, true},
+		{"turn off equal to threshold", Config{TemperatureThreshold: 60, TemperatureTurnOff: 60}, true},
+		{"invalid cron", Config{TemperatureThreshold: 60, TemperatureTurnOff: 50, WeeklyCheckCron: "nonsense"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateConfig(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateConfig(%+v) error = %v, wantErr %v", tt.cfg, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDiffConfigMasksSensitiveFields(t *testing.T) {
+	old := Config{Password: "old-secret", CheckInterval: 5}
+	new := Config{Password: "new-secret", CheckInterval: 10}
+
+	diffs := diffConfig(old, new)
+
+	foundInterval, foundPassword := false, false
+	for _, d := range diffs {
+		if d == "CheckInterval: 5 -> 10" {
+			foundInterval = true
+		}
+		if d == "Password changed" {
+			foundPassword = true
+		}
+		if d == "Password: old-secret -> new-secret" {
+			t.Fatalf("diffConfig leaked a sensitive field value: %q", d)
+		}
+	}
+	if !foundInterval {
+		t.Errorf("expected a CheckInterval diff, got %v", diffs)
+	}
+	if !foundPassword {
+		t.Errorf("expected a masked Password diff, got %v", diffs)
+	}
+}
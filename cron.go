@@ -0,0 +1,187 @@
+// cron.go
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule is a parsed cron expression that can compute its next fire time.
+// It supports the standard 5 fields (minute hour dom month dow) plus an optional
+// leading seconds field, each accepting `*`, comma lists, ranges (`a-b`) and steps
+// (`*/n` or `a-b/n`).
+type CronSchedule struct {
+	seconds map[int]bool
+	minutes map[int]bool
+	hours   map[int]bool
+	doms    map[int]bool
+	months  map[int]bool
+	dows    map[int]bool
+
+	domIsWildcard bool
+	dowIsWildcard bool
+}
+
+// ParseCron parses a 5-field cron expression ("minute hour dom month dow") or a
+// 6-field one with a leading seconds field ("second minute hour dom month dow").
+func ParseCron(expr string) (*CronSchedule, error) {
+	fields := strings.Fields(expr)
+
+	var secondField string
+	switch len(fields) {
+	case 5:
+		secondField = "0"
+	case 6:
+		secondField = fields[0]
+		fields = fields[1:]
+	default:
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 or 6 fields, got %d", expr, len(fields))
+	}
+
+	seconds, err := parseCronField(secondField, 0, 59)
+	if err != nil {
+		return nil, fmt.Errorf("invalid seconds field: %w", err)
+	}
+	minutes, err := parseCronField(fields[0], 0, 59)
+	if err != nil {
+		return nil, fmt.Errorf("invalid minute field: %w", err)
+	}
+	hours, err := parseCronField(fields[1], 0, 23)
+	if err != nil {
+		return nil, fmt.Errorf("invalid hour field: %w", err)
+	}
+	doms, err := parseCronField(fields[2], 1, 31)
+	if err != nil {
+		return nil, fmt.Errorf("invalid day-of-month field: %w", err)
+	}
+	months, err := parseCronField(fields[3], 1, 12)
+	if err != nil {
+		return nil, fmt.Errorf("invalid month field: %w", err)
+	}
+	dows, err := parseCronField(fields[4], 0, 6)
+	if err != nil {
+		return nil, fmt.Errorf("invalid day-of-week field: %w", err)
+	}
+
+	return &CronSchedule{
+		seconds:       seconds,
+		minutes:       minutes,
+		hours:         hours,
+		doms:          doms,
+		months:        months,
+		dows:          dows,
+		domIsWildcard: fields[2] == "*",
+		dowIsWildcard: fields[4] == "*",
+	}, nil
+}
+
+// parseCronField expands a single cron field into the set of matching integer values.
+func parseCronField(field string, min, max int) (map[int]bool, error) {
+	values := make(map[int]bool)
+
+	for _, part := range strings.Split(field, ",") {
+		rangePart := part
+		step := 1
+
+		if idx := strings.Index(part, "/"); idx >= 0 {
+			rangePart = part[:idx]
+			s, err := strconv.Atoi(part[idx+1:])
+			if err != nil || s <= 0 {
+				return nil, fmt.Errorf("invalid step in %q", part)
+			}
+			step = s
+		}
+
+		var start, end int
+		switch {
+		case rangePart == "*":
+			start, end = min, max
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			s, err := strconv.Atoi(bounds[0])
+			if err != nil {
+				return nil, fmt.Errorf("invalid range start in %q", part)
+			}
+			e, err := strconv.Atoi(bounds[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid range end in %q", part)
+			}
+			start, end = s, e
+		default:
+			v, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value %q", part)
+			}
+			start, end = v, v
+		}
+
+		if start < min || end > max || start > end {
+			return nil, fmt.Errorf("value %q out of range [%d,%d]", part, min, max)
+		}
+
+		for v := start; v <= end; v += step {
+			values[v] = true
+		}
+	}
+
+	return values, nil
+}
+
+// Next returns the first time strictly after `after` that matches the schedule, truncated
+// to whole seconds. It returns the zero time if no match is found within four years,
+// which only happens for a malformed or self-contradictory schedule.
+func (c *CronSchedule) Next(after time.Time) time.Time {
+	hours := sortedKeys(c.hours)
+	minutes := sortedKeys(c.minutes)
+	seconds := sortedKeys(c.seconds)
+
+	start := after.Truncate(time.Second)
+	dayStart := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+
+	const maxDays = 4 * 366
+	for day := 0; day < maxDays; day++ {
+		candidate := dayStart.AddDate(0, 0, day)
+		if !c.months[int(candidate.Month())] || !c.domDowMatches(candidate) {
+			continue
+		}
+
+		for _, h := range hours {
+			for _, m := range minutes {
+				for _, s := range seconds {
+					fireTime := time.Date(candidate.Year(), candidate.Month(), candidate.Day(), h, m, s, 0, candidate.Location())
+					if fireTime.After(start) {
+						return fireTime
+					}
+				}
+			}
+		}
+	}
+
+	return time.Time{}
+}
+
+// domDowMatches applies the standard cron rule for combining day-of-month and
+// day-of-week: if both fields are restricted (not `*`), a date matches if either
+// one matches; otherwise only the restricted field (if any) needs to match.
+func (c *CronSchedule) domDowMatches(t time.Time) bool {
+	domMatches := c.doms[t.Day()]
+	dowMatches := c.dows[int(t.Weekday())]
+
+	if !c.domIsWildcard && !c.dowIsWildcard {
+		return domMatches || dowMatches
+	}
+	return domMatches && dowMatches
+}
+
+func sortedKeys(set map[int]bool) []int {
+	keys := make([]int, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Ints(keys)
+	return keys
+}
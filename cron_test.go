@@ -0,0 +1,92 @@
+// cron_test.go
+
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func mustParseCron(t *testing.T, expr string) *CronSchedule {
+	t.Helper()
+	schedule, err := ParseCron(expr)
+	if err != nil {
+		t.Fatalf("ParseCron(%q) returned an error: %v", expr, err)
+	}
+	return schedule
+}
+
+func TestCronNextWeeklyOccurrence(t *testing.T) {
+	// "0 3 * * 1" = every Monday at 03:00:00.
+	schedule := mustParseCron(t, "0 3 * * 1")
+
+	// 2026-07-27 is a Monday; starting just after 03:00 should roll to the following Monday.
+	after := time.Date(2026, 7, 27, 3, 0, 1, 0, time.UTC)
+	next := schedule.Next(after)
+
+	want := time.Date(2026, 8, 3, 3, 0, 0, 0, time.UTC)
+	if !next.Equal(want) {
+		t.Errorf("Next(%v) = %v, want %v", after, next, want)
+	}
+}
+
+func TestCronNextSameDayIfStillAhead(t *testing.T) {
+	schedule := mustParseCron(t, "0 3 * * 1")
+
+	after := time.Date(2026, 7, 27, 1, 0, 0, 0, time.UTC)
+	next := schedule.Next(after)
+
+	want := time.Date(2026, 7, 27, 3, 0, 0, 0, time.UTC)
+	if !next.Equal(want) {
+		t.Errorf("Next(%v) = %v, want %v", after, next, want)
+	}
+}
+
+func TestCronStepAndListOperators(t *testing.T) {
+	// Every 15 minutes, at hours 8 and 20.
+	schedule := mustParseCron(t, "*/15 8,20 * * *")
+
+	after := time.Date(2026, 7, 27, 8, 16, 0, 0, time.UTC)
+	next := schedule.Next(after)
+
+	want := time.Date(2026, 7, 27, 8, 30, 0, 0, time.UTC)
+	if !next.Equal(want) {
+		t.Errorf("Next(%v) = %v, want %v", after, next, want)
+	}
+}
+
+func TestCronSixFieldWithSeconds(t *testing.T) {
+	schedule := mustParseCron(t, "30 0 3 * * 1")
+
+	// Just after this Monday's 03:00:30 occurrence, so it should roll to next Monday.
+	after := time.Date(2026, 7, 27, 3, 0, 31, 0, time.UTC)
+	next := schedule.Next(after)
+
+	want := time.Date(2026, 8, 3, 3, 0, 30, 0, time.UTC)
+	if !next.Equal(want) {
+		t.Errorf("Next(%v) = %v, want %v", after, next, want)
+	}
+}
+
+func TestCronInvalidExpression(t *testing.T) {
+	if _, err := ParseCron("not a cron"); err == nil {
+		t.Fatal("expected an error for a malformed cron expression")
+	}
+	if _, err := ParseCron("99 3 * * 1"); err == nil {
+		t.Fatal("expected an error for an out-of-range minute")
+	}
+}
+
+func TestCronDomDowOrSemantics(t *testing.T) {
+	// Both day-of-month and day-of-week restricted: should match either.
+	// 2026-07-27 is a Monday (dow=1), but not the 1st of the month.
+	schedule := mustParseCron(t, "0 0 1 * 1")
+
+	after := time.Date(2026, 7, 27, 0, 0, 0, 0, time.UTC)
+	next := schedule.Next(after)
+
+	want := time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC)
+	if !next.Equal(want) {
+		t.Errorf("Next(%v) = %v, want %v", after, next, want)
+	}
+}
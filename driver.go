@@ -0,0 +1,63 @@
+// driver.go
+
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+// ChargingMode is the high-level state a HeatPumpDriver is commanded into.
+type ChargingMode int
+
+const (
+	// ChargingModeOff stops the heat pump from heating/charging.
+	ChargingModeOff ChargingMode = iota
+	// ChargingModeOn starts the heat pump heating/charging.
+	ChargingModeOn
+)
+
+// String returns a human-readable name for the charging mode, used in logs and by the mqtt driver.
+func (m ChargingMode) String() string {
+	if m == ChargingModeOn {
+		return "on"
+	}
+	return "off"
+}
+
+// HeatPumpDriver abstracts over the backend used to control and monitor the heat pump,
+// letting HeatingManager stay agnostic of which smart-home ecosystem is in use.
+type HeatPumpDriver interface {
+	// SetChargingMode turns the heat pump on or off.
+	SetChargingMode(ctx context.Context, mode ChargingMode) error
+	// ReadTemperature returns the current water temperature.
+	ReadTemperature(ctx context.Context) (float64, error)
+	// Name identifies the driver, e.g. for logging.
+	Name() string
+}
+
+// driverFactory builds a HeatPumpDriver from the manager's configuration.
+type driverFactory func(cfg Config, hm *HeatingManager) (HeatPumpDriver, error)
+
+// driverFactories is the registry of driver names to their constructors.
+var driverFactories = map[string]driverFactory{
+	"solarmanager":  newSolarManagerDriver,
+	"mqtt":          newMQTTDriver,
+	"homeassistant": newHomeAssistantDriver,
+}
+
+// NewHeatPumpDriver builds the HeatPumpDriver named by Config.Driver, defaulting to
+// "solarmanager" so existing configs that don't set it keep working unchanged.
+func NewHeatPumpDriver(cfg Config, hm *HeatingManager) (HeatPumpDriver, error) {
+	name := cfg.Driver
+	if name == "" {
+		name = "solarmanager"
+	}
+
+	factory, ok := driverFactories[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown heat pump driver %q", name)
+	}
+
+	return factory(cfg, hm)
+}
@@ -0,0 +1,115 @@
+// driver_homeassistant.go
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// homeAssistantDriver is a HeatPumpDriver that controls a heat pump exposed as a Home
+// Assistant climate entity and reads temperature from a sensor entity, using Home
+// Assistant's REST API with a long-lived access token.
+type homeAssistantDriver struct {
+	baseURL             string
+	token               string
+	climateEntityID     string
+	temperatureEntityID string
+	client              *http.Client
+}
+
+func newHomeAssistantDriver(cfg Config, hm *HeatingManager) (HeatPumpDriver, error) {
+	if cfg.HomeAssistantURL == "" || cfg.HomeAssistantToken == "" {
+		return nil, fmt.Errorf("homeAssistantURL and homeAssistantToken are required for the homeassistant driver")
+	}
+	if cfg.HomeAssistantClimateEntityID == "" {
+		return nil, fmt.Errorf("homeAssistantClimateEntityID is required for the homeassistant driver")
+	}
+
+	return &homeAssistantDriver{
+		baseURL:             strings.TrimRight(cfg.HomeAssistantURL, "/"),
+		token:               cfg.HomeAssistantToken,
+		climateEntityID:     cfg.HomeAssistantClimateEntityID,
+		temperatureEntityID: cfg.HomeAssistantTemperatureEntityID,
+		client:              &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+func (d *homeAssistantDriver) Name() string { return "homeassistant" }
+
+// SetChargingMode calls the climate.turn_on/climate.turn_off service for the configured entity.
+func (d *homeAssistantDriver) SetChargingMode(ctx context.Context, mode ChargingMode) error {
+	service := "turn_off"
+	if mode == ChargingModeOn {
+		service = "turn_on"
+	}
+
+	url := fmt.Sprintf("%s/api/services/climate/%s", d.baseURL, service)
+	body, err := json.Marshal(map[string]string{"entity_id": d.climateEntityID})
+	if err != nil {
+		return fmt.Errorf("error marshalling request body: %v", err)
+	}
+
+	resp, err := d.doRequest(ctx, "POST", url, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to call climate.%s for %s: status code %d", service, d.climateEntityID, resp.StatusCode)
+	}
+
+	return nil
+}
+
+// ReadTemperature reads the state of the configured temperature sensor entity, falling
+// back to the climate entity itself if no separate temperature entity is configured.
+func (d *homeAssistantDriver) ReadTemperature(ctx context.Context) (float64, error) {
+	entityID := d.temperatureEntityID
+	if entityID == "" {
+		entityID = d.climateEntityID
+	}
+
+	url := fmt.Sprintf("%s/api/states/%s", d.baseURL, entityID)
+	resp, err := d.doRequest(ctx, "GET", url, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("failed to read state of %s: status code %d", entityID, resp.StatusCode)
+	}
+
+	var result struct {
+		State string `json:"state"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("failed to decode state response: %v", err)
+	}
+
+	temperature, err := strconv.ParseFloat(result.State, 64)
+	if err != nil {
+		return 0, fmt.Errorf("failed to parse temperature %q: %v", result.State, err)
+	}
+
+	return temperature, nil
+}
+
+func (d *homeAssistantDriver) doRequest(ctx context.Context, method, url string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+d.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	return d.client.Do(req)
+}
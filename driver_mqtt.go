@@ -0,0 +1,261 @@
+// driver_mqtt.go
+
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// mqttDriver is a HeatPumpDriver backed by a minimal, self-contained MQTT 3.1.1 client
+// (no external dependencies). It publishes charging-mode commands to a configurable
+// topic and subscribes to a temperature topic, for integration with home-automation
+// brokers such as Mosquitto or Home Assistant's built-in broker add-on.
+type mqttDriver struct {
+	conn   net.Conn
+	connMu sync.Mutex
+
+	chargingTopic    string
+	temperatureTopic string
+
+	tempMu      sync.Mutex
+	temperature float64
+	haveTemp    bool
+}
+
+func newMQTTDriver(cfg Config, hm *HeatingManager) (HeatPumpDriver, error) {
+	if cfg.MQTTBrokerAddress == "" {
+		return nil, fmt.Errorf("mqttBrokerAddress is required for the mqtt driver")
+	}
+
+	conn, err := net.DialTimeout("tcp", cfg.MQTTBrokerAddress, 10*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to MQTT broker: %v", err)
+	}
+
+	clientID := cfg.MQTTClientID
+	if clientID == "" {
+		clientID = "pv-heating-manager"
+	}
+
+	d := &mqttDriver{
+		conn:             conn,
+		chargingTopic:    cfg.MQTTChargingTopic,
+		temperatureTopic: cfg.MQTTTemperatureTopic,
+	}
+
+	if err := d.connect(clientID); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if d.temperatureTopic != "" {
+		if err := d.subscribe(d.temperatureTopic); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		go d.readLoop()
+	}
+
+	return d, nil
+}
+
+func (d *mqttDriver) Name() string { return "mqtt" }
+
+// connect sends the MQTT CONNECT packet and waits for a successful CONNACK.
+func (d *mqttDriver) connect(clientID string) error {
+	variableHeader := append([]byte{}, encodeUTF8String("MQTT")...)
+	variableHeader = append(variableHeader, 0x04)       // protocol level 4 (3.1.1)
+	variableHeader = append(variableHeader, 0x02)       // connect flags: clean session
+	variableHeader = append(variableHeader, 0x00, 0x3c) // keep alive: 60s
+
+	payload := encodeUTF8String(clientID)
+
+	if err := d.writePacket(0x10, append(variableHeader, payload...)); err != nil {
+		return fmt.Errorf("error sending MQTT CONNECT: %v", err)
+	}
+
+	packetType, body, err := d.readPacket()
+	if err != nil {
+		return fmt.Errorf("error reading CONNACK: %v", err)
+	}
+	if packetType != 0x20 {
+		return fmt.Errorf("expected CONNACK, got MQTT packet type %#x", packetType)
+	}
+	if len(body) < 2 || body[1] != 0x00 {
+		return fmt.Errorf("MQTT broker rejected connection, return code %d", body[len(body)-1])
+	}
+
+	return nil
+}
+
+// subscribe sends a SUBSCRIBE packet for topic at QoS 0 and waits for the SUBACK.
+func (d *mqttDriver) subscribe(topic string) error {
+	variableHeader := []byte{0x00, 0x01} // packet identifier
+
+	payload := append([]byte{}, encodeUTF8String(topic)...)
+	payload = append(payload, 0x00) // requested QoS 0
+
+	if err := d.writePacket(0x82, append(variableHeader, payload...)); err != nil {
+		return fmt.Errorf("error sending MQTT SUBSCRIBE: %v", err)
+	}
+
+	packetType, _, err := d.readPacket()
+	if err != nil {
+		return fmt.Errorf("error reading SUBACK: %v", err)
+	}
+	if packetType != 0x90 {
+		return fmt.Errorf("expected SUBACK, got MQTT packet type %#x", packetType)
+	}
+
+	return nil
+}
+
+// publish sends a QoS 0 PUBLISH packet with the given topic and payload.
+func (d *mqttDriver) publish(topic string, payload []byte) error {
+	variableHeader := encodeUTF8String(topic)
+	return d.writePacket(0x30, append(variableHeader, payload...))
+}
+
+// writePacket assembles a fixed header (packet type + remaining length) and body, and writes it.
+func (d *mqttDriver) writePacket(packetType byte, body []byte) error {
+	packet := append([]byte{packetType}, encodeRemainingLength(len(body))...)
+	packet = append(packet, body...)
+
+	d.connMu.Lock()
+	defer d.connMu.Unlock()
+	_, err := d.conn.Write(packet)
+	return err
+}
+
+// readPacket reads one MQTT packet and returns its packet type (upper nibble of the
+// fixed header's first byte) and body.
+func (d *mqttDriver) readPacket() (byte, []byte, error) {
+	header := make([]byte, 1)
+	if _, err := io.ReadFull(d.conn, header); err != nil {
+		return 0, nil, err
+	}
+
+	remaining, err := readRemainingLength(d.conn)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	body := make([]byte, remaining)
+	if _, err := io.ReadFull(d.conn, body); err != nil {
+		return 0, nil, err
+	}
+
+	return header[0] & 0xf0, body, nil
+}
+
+// readLoop reads incoming packets for as long as the connection is alive, updating the
+// cached temperature whenever a PUBLISH arrives on temperatureTopic.
+func (d *mqttDriver) readLoop() {
+	for {
+		packetType, body, err := d.readPacket()
+		if err != nil {
+			log.Printf("MQTT read loop stopped: %v", err)
+			return
+		}
+		if packetType == 0x30 { // PUBLISH
+			d.handlePublish(body)
+		}
+	}
+}
+
+func (d *mqttDriver) handlePublish(body []byte) {
+	if len(body) < 2 {
+		return
+	}
+	topicLen := int(binary.BigEndian.Uint16(body[:2]))
+	if len(body) < 2+topicLen {
+		return
+	}
+	topic := string(body[2 : 2+topicLen])
+	if topic != d.temperatureTopic {
+		return
+	}
+	payload := body[2+topicLen:]
+
+	temp, err := strconv.ParseFloat(strings.TrimSpace(string(payload)), 64)
+	if err != nil {
+		log.Printf("Failed to parse MQTT temperature payload %q on topic %q: %v", payload, topic, err)
+		return
+	}
+
+	d.tempMu.Lock()
+	d.temperature = temp
+	d.haveTemp = true
+	d.tempMu.Unlock()
+}
+
+// SetChargingMode publishes the charging mode as a plain-text payload ("on"/"off") to chargingTopic.
+func (d *mqttDriver) SetChargingMode(ctx context.Context, mode ChargingMode) error {
+	if d.chargingTopic == "" {
+		return fmt.Errorf("mqttChargingTopic is not configured")
+	}
+	return d.publish(d.chargingTopic, []byte(mode.String()))
+}
+
+// ReadTemperature returns the most recent temperature reading received on temperatureTopic.
+func (d *mqttDriver) ReadTemperature(ctx context.Context) (float64, error) {
+	d.tempMu.Lock()
+	defer d.tempMu.Unlock()
+	if !d.haveTemp {
+		return 0, fmt.Errorf("no temperature reading received yet on topic %q", d.temperatureTopic)
+	}
+	return d.temperature, nil
+}
+
+// encodeUTF8String encodes s as an MQTT UTF-8 string: a 2-byte big-endian length prefix
+// followed by the raw bytes.
+func encodeUTF8String(s string) []byte {
+	b := make([]byte, 2+len(s))
+	binary.BigEndian.PutUint16(b[:2], uint16(len(s)))
+	copy(b[2:], s)
+	return b
+}
+
+// encodeRemainingLength encodes length using the MQTT variable-length integer scheme.
+func encodeRemainingLength(length int) []byte {
+	var out []byte
+	for {
+		b := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			b |= 0x80
+		}
+		out = append(out, b)
+		if length == 0 {
+			break
+		}
+	}
+	return out
+}
+
+// readRemainingLength decodes an MQTT variable-length integer from r.
+func readRemainingLength(r io.Reader) (int, error) {
+	multiplier := 1
+	value := 0
+	b := make([]byte, 1)
+	for {
+		if _, err := io.ReadFull(r, b); err != nil {
+			return 0, err
+		}
+		value += int(b[0]&0x7f) * multiplier
+		if b[0]&0x80 == 0 {
+			break
+		}
+		multiplier *= 128
+	}
+	return value, nil
+}
@@ -0,0 +1,125 @@
+// driver_solarmanager.go
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// solarManagerDriver is the original HeatPumpDriver implementation, talking to the
+// Solar Manager REST API. It reuses HeatingManager's existing auth token management.
+type solarManagerDriver struct {
+	hm *HeatingManager
+}
+
+func newSolarManagerDriver(cfg Config, hm *HeatingManager) (HeatPumpDriver, error) {
+	return &solarManagerDriver{hm: hm}, nil
+}
+
+func (d *solarManagerDriver) Name() string { return "solarmanager" }
+
+// SetChargingMode sets the heat pump's charging mode via a PUT request to HeatPumpControlURL.
+func (d *solarManagerDriver) SetChargingMode(ctx context.Context, mode ChargingMode) error {
+	token, err := d.hm.getAuthToken()
+	if err != nil {
+		return fmt.Errorf("error getting auth token: %v", err)
+	}
+
+	chargingMode := 2 // off
+	if mode == ChargingModeOn {
+		chargingMode = 1
+	}
+
+	cfg := d.hm.Cfg()
+	url := fmt.Sprintf(cfg.HeatPumpControlURL, cfg.HeatPumpID)
+	requestBody, err := json.Marshal(map[string]interface{}{
+		"heatPumpChargingMode": chargingMode,
+	})
+	if err != nil {
+		return fmt.Errorf("error marshalling request body: %v", err)
+	}
+
+	return d.makeHeatingControlRequest(ctx, token, url, requestBody)
+}
+
+// makeHeatingControlRequest makes a PUT request to control the heat pump.
+func (d *solarManagerDriver) makeHeatingControlRequest(ctx context.Context, token, url string, requestBody []byte) error {
+	start := time.Now()
+	defer func() {
+		d.hm.Metrics.ObserveRequestLatency("heat_pump_control", time.Since(start))
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewBuffer(requestBody))
+	if err != nil {
+		return fmt.Errorf("error creating request: %v", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("error executing request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("failed to modify heat pump state, status code: %d", resp.StatusCode)
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		fmt.Println("Heat pump state changed successfully.")
+	}
+	return nil
+}
+
+// ReadTemperature fetches the current water temperature from the Solar Manager sensor API.
+func (d *solarManagerDriver) ReadTemperature(ctx context.Context) (float64, error) {
+	token, err := d.hm.getAuthToken()
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	defer func() {
+		d.hm.Metrics.ObserveRequestLatency("solar_manager_temperature", time.Since(start))
+	}()
+
+	cfg := d.hm.Cfg()
+	url := fmt.Sprintf("%s/%s", cfg.SolarManagerURL, cfg.SolarManagerSensorID)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error creating request: %v", err)
+	}
+
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get temperature: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("failed to get temperature: status code %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Data struct {
+			CurrentWaterTemp float64 `json:"currentWaterTemp"`
+		} `json:"data"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal temperature response: %v", err)
+	}
+
+	return result.Data.CurrentWaterTemp, nil
+}
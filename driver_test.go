@@ -0,0 +1,98 @@
+// driver_test.go
+
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewHeatPumpDriverDefaultsToSolarManager(t *testing.T) {
+	hm := &HeatingManager{}
+	driver, err := NewHeatPumpDriver(Config{}, hm)
+	if err != nil {
+		t.Fatalf("NewHeatPumpDriver returned an error: %v", err)
+	}
+	if driver.Name() != "solarmanager" {
+		t.Fatalf("expected default driver %q, got %q", "solarmanager", driver.Name())
+	}
+}
+
+func TestNewHeatPumpDriverUnknownName(t *testing.T) {
+	if _, err := NewHeatPumpDriver(Config{Driver: "does-not-exist"}, &HeatingManager{}); err == nil {
+		t.Fatal("expected an error for an unknown driver name")
+	}
+}
+
+func TestChargingModeString(t *testing.T) {
+	if ChargingModeOn.String() != "on" {
+		t.Errorf("ChargingModeOn.String() = %q, want %q", ChargingModeOn.String(), "on")
+	}
+	if ChargingModeOff.String() != "off" {
+		t.Errorf("ChargingModeOff.String() = %q, want %q", ChargingModeOff.String(), "off")
+	}
+}
+
+func TestHomeAssistantDriver(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/services/climate/turn_on", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/api/states/sensor.boiler_temperature", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"state": "57.3"}`))
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	driver, err := NewHeatPumpDriver(Config{
+		Driver:                           "homeassistant",
+		HomeAssistantURL:                 server.URL,
+		HomeAssistantToken:               "test-token",
+		HomeAssistantClimateEntityID:     "climate.boiler",
+		HomeAssistantTemperatureEntityID: "sensor.boiler_temperature",
+	}, &HeatingManager{})
+	if err != nil {
+		t.Fatalf("NewHeatPumpDriver returned an error: %v", err)
+	}
+
+	if err := driver.SetChargingMode(context.Background(), ChargingModeOn); err != nil {
+		t.Fatalf("SetChargingMode returned an error: %v", err)
+	}
+
+	temp, err := driver.ReadTemperature(context.Background())
+	if err != nil {
+		t.Fatalf("ReadTemperature returned an error: %v", err)
+	}
+	if temp != 57.3 {
+		t.Errorf("ReadTemperature() = %v, want 57.3", temp)
+	}
+}
+
+func TestMQTTRemainingLengthRoundTrip(t *testing.T) {
+	for _, length := range []int{0, 1, 127, 128, 16383, 16384, 2097151} {
+		encoded := encodeRemainingLength(length)
+		decoded, err := readRemainingLength(&byteSliceReader{data: encoded})
+		if err != nil {
+			t.Fatalf("readRemainingLength(%v) returned an error: %v", encoded, err)
+		}
+		if decoded != length {
+			t.Errorf("round-tripped remaining length = %d, want %d", decoded, length)
+		}
+	}
+}
+
+// byteSliceReader is a minimal io.Reader over a byte slice, used to exercise
+// readRemainingLength without a real network connection.
+type byteSliceReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *byteSliceReader) Read(p []byte) (int, error) {
+	n := copy(p, r.data[r.pos:])
+	r.pos += n
+	return n, nil
+}
@@ -3,93 +3,115 @@
 package main
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"net/http"
-	"time"
+	"log"
 )
 
-// HeatingManager represents the heating manager.
-type HeatingManager struct {
-	Config        Config
-	Token         string
-	TokenExpiry   time.Time
-	CheckInterval time.Duration
-	LastCheckFile string
-}
+// defaultHeatingDebounceChecks is used when Config.HeatingOnDebounceChecks or
+// Config.HeatingOffDebounceChecks is not set.
+const defaultHeatingDebounceChecks = 3
 
-// turnHeatingOn turns on the heating.
-// It gets an authentication token, constructs the request URL and body,
-// and makes a PUT request to control the heating system.
-// Returns an error if any of the steps fail.
+// turnHeatingOn commands the configured HeatPumpDriver to start heating/charging.
 func (hm *HeatingManager) turnHeatingOn() error {
-	// Get authentication token
-	if err := hm.getAuthToken(); err != nil {
-		return fmt.Errorf("error getting auth token: %v", err)
-	}
-
-	// Construct request URL and body
-	url := fmt.Sprintf(hm.Config.HeatPumpControlURL, hm.Config.HeatPumpID)
-	requestBody, err := json.Marshal(map[string]interface{}{
-		"heatPumpChargingMode": 1,
-	})
-	if err != nil {
-		return fmt.Errorf("error marshalling request body: %v", err)
-	}
-
-	// Make PUT request to control the heating system
-	return hm.makeHeatingControlRequest(url, requestBody)
+	return hm.setChargingMode(ChargingModeOn)
 }
 
-// turnHeatingOff turns off the heating.
-// Steps are similar to turnHeatingOn().
+// turnHeatingOff commands the configured HeatPumpDriver to stop heating/charging.
 func (hm *HeatingManager) turnHeatingOff() error {
-	if err := hm.getAuthToken(); err != nil {
-		return fmt.Errorf("error getting auth token: %v", err)
+	return hm.setChargingMode(ChargingModeOff)
+}
+
+// setChargingMode delegates to the active HeatPumpDriver and records the resulting state.
+func (hm *HeatingManager) setChargingMode(mode ChargingMode) error {
+	if err := hm.Driver.SetChargingMode(context.Background(), mode); err != nil {
+		return fmt.Errorf("error setting charging mode on %s driver: %v", hm.Driver.Name(), err)
 	}
+	hm.Metrics.SetHeatPumpOn(mode == ChargingModeOn)
+	return nil
+}
 
-	url := fmt.Sprintf(hm.Config.HeatPumpControlURL, hm.Config.HeatPumpID)
-	requestBody, err := json.Marshal(map[string]interface{}{
-		"heatPumpChargingMode": 2,
-	})
+// updateHeatingControl runs the hysteresis-driven heating control loop: it turns the heat
+// pump on when there is enough PV surplus to heat opportunistically, and off once the water
+// is hot enough or surplus disappears. Both conditions are debounced over consecutive checks
+// to avoid short-lived readings flapping the heat pump, except the turn-off-on-overheat case,
+// which is safety-critical and always acts immediately.
+func (hm *HeatingManager) updateHeatingControl(temperature float64, cfg Config) {
+	surplus, err := hm.getPVSurplus()
 	if err != nil {
-		return fmt.Errorf("error marshalling request body: %v", err)
+		log.Printf("Failed to get PV surplus, treating it as zero for this check: %v", err)
+		surplus = 0
 	}
+	hm.Metrics.SetPVSurplusWatts(surplus)
 
-	return hm.makeHeatingControlRequest(url, requestBody)
-}
+	onDebounce := cfg.HeatingOnDebounceChecks
+	if onDebounce <= 0 {
+		onDebounce = defaultHeatingDebounceChecks
+	}
+	offDebounce := cfg.HeatingOffDebounceChecks
+	if offDebounce <= 0 {
+		offDebounce = defaultHeatingDebounceChecks
+	}
 
-// makeHeatingControlRequest makes a request to control the heating system.
-// The request is a PUT request with the provided URL and request body.
-// Returns an error if the request fails.
-func (hm *HeatingManager) makeHeatingControlRequest(url string, requestBody []byte) error {
-	// Create request
-	req, err := http.NewRequest("PUT", url, bytes.NewBuffer(requestBody))
-	if err != nil {
-		return fmt.Errorf("error creating request: %v", err)
+	hm.stateMu.Lock()
+	if temperature < cfg.TemperatureThreshold && surplus > cfg.MinSurplusWatts {
+		hm.surplusOnStreak++
+	} else {
+		hm.surplusOnStreak = 0
 	}
 
-	// Set request headers
-	req.Header.Set("Authorization", "Bearer "+hm.Token)
-	req.Header.Set("Content-Type", "application/json")
+	if surplus <= cfg.MinSurplusWatts {
+		hm.surplusOffStreak++
+	} else {
+		hm.surplusOffStreak = 0
+	}
 
-	// Execute request
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("error executing request: %v", err)
+	change, on := false, false
+	switch {
+	case temperature >= cfg.TemperatureTurnOff:
+		change, on = true, false
+	case !hm.heatingCommandedOn && hm.surplusOnStreak >= onDebounce:
+		change, on = true, true
+	case hm.heatingCommandedOn && hm.surplusOffStreak >= offDebounce:
+		change, on = true, false
 	}
-	defer resp.Body.Close()
+	hm.stateMu.Unlock()
 
-	// Check response status code
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
-		return fmt.Errorf("failed to modify heat pump state, status code: %d", resp.StatusCode)
+	if change {
+		hm.commandHeating(on)
 	}
+}
 
-	// Print success message if status code is OK
-	if resp.StatusCode == http.StatusOK {
-		fmt.Println("Heat pump state changed successfully.")
+// commandHeating turns the heat pump on or off, skipping the call entirely if it is
+// already in the requested state so the driver doesn't see redundant PUT requests.
+// It is the only place that updates heatingCommandedOn, and it resets the debounce
+// streak counters after each successful command, so callers should always go through
+// it rather than calling turnHeatingOn/turnHeatingOff directly, or those fields fall
+// out of sync with the heat pump's actual commanded state.
+func (hm *HeatingManager) commandHeating(on bool) error {
+	hm.stateMu.Lock()
+	alreadyCommanded := hm.heatingCommandedOn == on
+	hm.stateMu.Unlock()
+	if alreadyCommanded {
+		return nil
 	}
+
+	var mode ChargingMode
+	var err error
+	if on {
+		mode, err = ChargingModeOn, hm.turnHeatingOn()
+	} else {
+		mode, err = ChargingModeOff, hm.turnHeatingOff()
+	}
+	if err != nil {
+		log.Printf("Failed to command heating %s: %v", mode, err)
+		return err
+	}
+
+	hm.stateMu.Lock()
+	hm.heatingCommandedOn = on
+	hm.surplusOnStreak = 0
+	hm.surplusOffStreak = 0
+	hm.stateMu.Unlock()
 	return nil
 }
@@ -0,0 +1,136 @@
+// heating_control_test.go
+
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newControlTestHeatingManager builds a HeatingManager wired to mock PV-surplus and
+// heat-pump-control servers, with a pre-populated auth token so no real login request
+// is made.
+func newControlTestHeatingManager(t *testing.T, surplusServer, controlServer *httptest.Server, temperatureTurnOff float64) *HeatingManager {
+	t.Helper()
+
+	cfg := Config{
+		HeatPumpControlURL:       controlServer.URL + "/%s",
+		HeatPumpID:               "pump-1",
+		PVSurplusURL:             surplusServer.URL,
+		TemperatureThreshold:     60,
+		TemperatureTurnOff:       temperatureTurnOff,
+		MinSurplusWatts:          500,
+		HeatingOnDebounceChecks:  2,
+		HeatingOffDebounceChecks: 2,
+	}
+
+	hm := &HeatingManager{
+		cfg:         cfg,
+		Token:       "test-token",
+		TokenExpiry: time.Now().Add(time.Hour),
+		Metrics:     NewMetrics(),
+	}
+
+	driver, err := NewHeatPumpDriver(cfg, hm)
+	if err != nil {
+		t.Fatalf("NewHeatPumpDriver returned an error: %v", err)
+	}
+	hm.Driver = driver
+
+	return hm
+}
+
+func jsonSurplusServer(t *testing.T, surplusWatt float64) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"data":{"surplusWatt":%g}}`, surplusWatt)
+	}))
+}
+
+func countingControlServer(t *testing.T, counter *int) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*counter++
+		w.WriteHeader(http.StatusNoContent)
+	}))
+}
+
+func TestUpdateHeatingControlTurnsOnAfterDebounce(t *testing.T) {
+	var controlCalls int
+	controlServer := countingControlServer(t, &controlCalls)
+	defer controlServer.Close()
+
+	surplusServer := jsonSurplusServer(t, 800)
+	defer surplusServer.Close()
+
+	hm := newControlTestHeatingManager(t, surplusServer, controlServer, 65)
+	cfg := hm.Cfg()
+
+	hm.updateHeatingControl(55, cfg)
+	if hm.heatingCommandedOn {
+		t.Fatal("expected heating to stay off before the on-debounce is satisfied")
+	}
+
+	hm.updateHeatingControl(55, cfg)
+	if !hm.heatingCommandedOn {
+		t.Fatal("expected heating to turn on once the on-debounce is satisfied")
+	}
+	if controlCalls != 1 {
+		t.Fatalf("expected exactly 1 control request, got %d", controlCalls)
+	}
+
+	hm.updateHeatingControl(55, cfg)
+	if controlCalls != 1 {
+		t.Fatalf("expected no redundant control request once already on, got %d calls", controlCalls)
+	}
+}
+
+func TestUpdateHeatingControlTurnsOffImmediatelyAboveTurnOff(t *testing.T) {
+	var controlCalls int
+	controlServer := countingControlServer(t, &controlCalls)
+	defer controlServer.Close()
+
+	surplusServer := jsonSurplusServer(t, 800)
+	defer surplusServer.Close()
+
+	hm := newControlTestHeatingManager(t, surplusServer, controlServer, 50)
+	hm.heatingCommandedOn = true
+
+	hm.updateHeatingControl(51, hm.Cfg())
+	if hm.heatingCommandedOn {
+		t.Fatal("expected heating to turn off immediately once temperature reaches TemperatureTurnOff")
+	}
+	if controlCalls != 1 {
+		t.Fatalf("expected exactly 1 control request, got %d", controlCalls)
+	}
+}
+
+func TestUpdateHeatingControlTurnsOffAfterSurplusDebounce(t *testing.T) {
+	var controlCalls int
+	controlServer := countingControlServer(t, &controlCalls)
+	defer controlServer.Close()
+
+	surplusServer := jsonSurplusServer(t, 100)
+	defer surplusServer.Close()
+
+	hm := newControlTestHeatingManager(t, surplusServer, controlServer, 65)
+	hm.heatingCommandedOn = true
+	cfg := hm.Cfg()
+
+	hm.updateHeatingControl(55, cfg)
+	if !hm.heatingCommandedOn {
+		t.Fatal("expected heating to stay on before the off-debounce is satisfied")
+	}
+
+	hm.updateHeatingControl(55, cfg)
+	if hm.heatingCommandedOn {
+		t.Fatal("expected heating to turn off once the surplus-low debounce is satisfied")
+	}
+	if controlCalls != 1 {
+		t.Fatalf("expected exactly 1 control request, got %d", controlCalls)
+	}
+}
@@ -3,18 +3,82 @@ package main
 import (
 	"fmt"
 	"log"
+	"math/rand"
+	"net/http"
 	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 	"time"
 )
 
 // HeatingManager represents the main logic of the heating manager.
 type HeatingManager struct {
-	Config              Config // Configuration for the heating manager.
-	TemperatureExceeded bool   // Flag indicating if the temperature threshold has been exceeded.
-	CheckInterval       time.Duration
-	LastCheckFile       string    // File to save and read the last check time from.
-	Token               string    // Token for authentication with the heating API.
-	TokenExpiry         time.Time // Expiration time of the token.
+	cfg   Config       // Current configuration; always access via Cfg()/setCfg, never directly.
+	cfgMu sync.RWMutex // Guards cfg so StartConfigWatcher can hot-reload it while other goroutines read it.
+
+	LastCheckFile string    // File to save and read the last check time from.
+	Token         string    // Token for authentication with the heating API.
+	RefreshToken  string    // Refresh token issued alongside Token, if any.
+	TokenExpiry   time.Time // Expiration time of the token.
+
+	stateMu sync.Mutex // Guards the four fields below: the temperature-monitoring and weekly-check
+	// goroutines write them while the admin server's handleStatus reads them concurrently.
+	// Access them only with stateMu held: through the isX/setX helpers below, or, in
+	// updateHeatingControl and commandHeating, by locking stateMu directly.
+	temperatureExceeded bool // Flag indicating if the temperature threshold has been exceeded.
+	heatingCommandedOn  bool // Current commanded heat-pump state, tracked to avoid redundant SetChargingMode calls.
+	surplusOnStreak     int  // Consecutive checks satisfying the PV-surplus turn-on condition.
+	surplusOffStreak    int  // Consecutive checks satisfying the PV-surplus turn-off condition.
+
+	TokenStore TokenStore // Persists Token/RefreshToken/TokenExpiry across restarts.
+	refreshMu  sync.Mutex // Serializes getAuthToken so concurrent callers can't trigger duplicate refreshes.
+
+	Driver HeatPumpDriver // Backend used to control and monitor the heat pump.
+
+	Metrics *Metrics // Prometheus-style metrics collected throughout the manager's lifetime.
+
+	adminServer *http.Server // Admin/metrics HTTP server, set once StartAdminServer runs.
+}
+
+// Cfg returns a copy of the current configuration. Safe for concurrent use; callers
+// that need several fields to stay consistent with each other should call it once
+// and reuse the result rather than calling Cfg() repeatedly.
+func (hm *HeatingManager) Cfg() Config {
+	hm.cfgMu.RLock()
+	defer hm.cfgMu.RUnlock()
+	return hm.cfg
+}
+
+// setCfg atomically replaces the current configuration. Used by StartConfigWatcher
+// once a reloaded configuration has passed validateConfig.
+func (hm *HeatingManager) setCfg(cfg Config) {
+	hm.cfgMu.Lock()
+	defer hm.cfgMu.Unlock()
+	hm.cfg = cfg
+}
+
+// isTemperatureExceeded reports whether the most recent checkTemperature found the
+// water temperature above Config.TemperatureThreshold.
+func (hm *HeatingManager) isTemperatureExceeded() bool {
+	hm.stateMu.Lock()
+	defer hm.stateMu.Unlock()
+	return hm.temperatureExceeded
+}
+
+// setTemperatureExceeded records whether the water temperature currently exceeds
+// Config.TemperatureThreshold.
+func (hm *HeatingManager) setTemperatureExceeded(exceeded bool) {
+	hm.stateMu.Lock()
+	defer hm.stateMu.Unlock()
+	hm.temperatureExceeded = exceeded
+}
+
+// isHeatingCommandedOn reports the heat pump's current commanded state, as last set by commandHeating.
+func (hm *HeatingManager) isHeatingCommandedOn() bool {
+	hm.stateMu.Lock()
+	defer hm.stateMu.Unlock()
+	return hm.heatingCommandedOn
 }
 
 // NewHeatingManager creates a new HeatingManager instance.
@@ -24,28 +88,71 @@ func NewHeatingManager() (*HeatingManager, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := validateConfig(config); err != nil {
+		return nil, fmt.Errorf("invalid configuration: %w", err)
+	}
 
-	return &HeatingManager{
-		Config:        config,
-		CheckInterval: time.Duration(config.CheckInterval) * time.Minute,
+	tokenCacheFile := config.TokenCacheFile
+	if tokenCacheFile == "" {
+		tokenCacheFile = "tokenCache.json"
+	}
+
+	hm := &HeatingManager{
+		cfg:           config,
 		LastCheckFile: "lastCheck.txt",
-	}, nil
+		Metrics:       NewMetrics(),
+		TokenStore:    NewFileTokenStore(tokenCacheFile, config.TokenEncryptionSecret),
+	}
+	hm.Metrics.SetTemperatureThreshold(config.TemperatureThreshold)
+
+	if cached, err := hm.TokenStore.Load(); err == nil {
+		hm.Token = cached.AccessToken
+		hm.RefreshToken = cached.RefreshToken
+		hm.TokenExpiry = cached.TokenExpiry
+	} else {
+		log.Printf("No usable cached auth token (%v), will log in on first use", err)
+	}
+
+	driver, err := NewHeatPumpDriver(config, hm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize heat pump driver: %w", err)
+	}
+	hm.Driver = driver
+
+	return hm, nil
 }
 
 // StartTemperatureMonitoring starts the temperature monitoring loop.
 // It checks the temperature at regular intervals.
 func (hm *HeatingManager) StartTemperatureMonitoring() {
-	ticker := time.NewTicker(hm.CheckInterval)
+	interval := time.Duration(hm.Cfg().CheckInterval) * time.Minute
+	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
 
 	for range ticker.C {
 		hm.checkTemperature()
+
+		if next := time.Duration(hm.Cfg().CheckInterval) * time.Minute; next != interval {
+			log.Printf("checkInterval changed, resetting monitoring ticker to %s", next)
+			interval = next
+			ticker.Reset(interval)
+		}
 	}
 }
 
-// StartWeeklyCheck starts the weekly check loop.
-// It checks if the temperature threshold has been exceeded and turns on the heating if necessary.
+// defaultHeatingOnDuration is used when Config.HeatingOnDuration is not set.
+const defaultHeatingOnDuration = 4 * time.Hour
+
+// StartWeeklyCheck starts the weekly check loop, scheduled by Config.WeeklyCheckCron.
+// On startup, if the last successful run is already overdue for its next occurrence, it
+// runs a catch-up check immediately — important for devices that were powered off through
+// their scheduled window.
 func (hm *HeatingManager) StartWeeklyCheck() {
+	if hm.weeklyCheckIsOverdue() {
+		log.Println("Last weekly check is overdue, running a catch-up check now")
+		hm.weeklyCheck()
+	}
+
 	weeklyCheckTimer := time.NewTimer(hm.nextWeeklyCheckDuration())
 	defer weeklyCheckTimer.Stop()
 
@@ -55,23 +162,48 @@ func (hm *HeatingManager) StartWeeklyCheck() {
 	}
 }
 
+// weeklyCheckIsOverdue reports whether the occurrence following the last successful run
+// has already passed, meaning the device likely missed it (e.g. it was powered off).
+func (hm *HeatingManager) weeklyCheckIsOverdue() bool {
+	lastCheck, err := hm.readLastCheckTime()
+	if err != nil {
+		return false
+	}
+
+	cron := hm.Cfg().WeeklyCheckCron
+	schedule, err := ParseCron(cron)
+	if err != nil {
+		log.Printf("Invalid weeklyCheckCron %q, skipping catch-up check: %v", cron, err)
+		return false
+	}
+
+	next := schedule.Next(lastCheck)
+	return !next.IsZero() && next.Before(time.Now())
+}
+
 // weeklyCheck checks if the temperature threshold has been exceeded and turns on the heating if necessary.
-// It also schedules to turn off the heating after a certain duration.
+// It also schedules to turn off the heating after Config.HeatingOnDuration.
 func (hm *HeatingManager) weeklyCheck() {
-	if !hm.TemperatureExceeded {
-		if err := hm.turnHeatingOn(); err != nil {
-			log.Printf("Failed to turn on heating: %v", err)
+	success := true
+	if !hm.isTemperatureExceeded() {
+		if err := hm.commandHeating(true); err != nil {
+			success = false
 		}
 
-		// Schedule to turn off after 4 hours
-		time.AfterFunc(4*time.Hour, func() {
-			if err := hm.turnHeatingOff(); err != nil {
-				log.Printf("Failed to turn off heating: %v", err)
-			}
+		onDuration := defaultHeatingOnDuration
+		if minutes := hm.Cfg().HeatingOnDuration; minutes > 0 {
+			onDuration = time.Duration(minutes) * time.Minute
+		}
+
+		time.AfterFunc(onDuration, func() {
+			hm.commandHeating(false)
 		})
 	}
-	hm.TemperatureExceeded = false
-	hm.saveLastCheckTime()
+	hm.Metrics.IncWeeklyCheck(success)
+	hm.setTemperatureExceeded(false)
+	if success {
+		hm.saveLastCheckTime()
+	}
 }
 
 // saveLastCheckTime saves the last check time to a file.
@@ -83,18 +215,28 @@ func (hm *HeatingManager) saveLastCheckTime() {
 	}
 }
 
-// nextWeeklyCheckDuration calculates the duration until the next weekly check.
-// It returns 0 if the next check time has already passed.
+// nextWeeklyCheckDuration calculates the duration until the next occurrence of
+// Config.WeeklyCheckCron, plus a random jitter of up to Config.WeeklyCheckJitter seconds
+// to spread load across devices. It falls back to 1 hour if the cron expression is invalid.
 func (hm *HeatingManager) nextWeeklyCheckDuration() time.Duration {
-	lastCheck, err := hm.readLastCheckTime()
+	cfg := hm.Cfg()
+	schedule, err := ParseCron(cfg.WeeklyCheckCron)
 	if err != nil {
-		return 0
+		log.Printf("Invalid weeklyCheckCron %q, defaulting to 1 hour: %v", cfg.WeeklyCheckCron, err)
+		return time.Hour
+	}
+
+	next := schedule.Next(time.Now())
+	if next.IsZero() {
+		log.Printf("weeklyCheckCron %q has no upcoming occurrence, defaulting to 1 hour", cfg.WeeklyCheckCron)
+		return time.Hour
 	}
-	nextCheck := lastCheck.Add(time.Duration(hm.Config.WeeklyCheckInterval) * time.Hour)
-	if time.Now().After(nextCheck) {
-		return 0
+
+	duration := time.Until(next)
+	if cfg.WeeklyCheckJitter > 0 {
+		duration += time.Duration(rand.Intn(cfg.WeeklyCheckJitter+1)) * time.Second
 	}
-	return time.Until(nextCheck)
+	return duration
 }
 
 // readLastCheckTime reads the last check time from a file.
@@ -111,3 +253,66 @@ func (hm *HeatingManager) readLastCheckTime() (time.Time, error) {
 
 	return lastCheck, nil
 }
+
+// configWatchInterval is how often StartConfigWatcher polls config.json's mtime
+// for changes, as a fallback for deployments that can't deliver SIGHUP.
+const configWatchInterval = 30 * time.Second
+
+// StartConfigWatcher reloads the configuration when config.json changes on disk or
+// the process receives SIGHUP, without requiring a restart. A reload that fails
+// validation is logged and rejected, leaving the previous configuration in effect.
+func (hm *HeatingManager) StartConfigWatcher() {
+	sighup := make(chan os.Signal, 1)
+	signal.Notify(sighup, syscall.SIGHUP)
+
+	lastMod := configFileModTime()
+	poll := time.NewTicker(configWatchInterval)
+	defer poll.Stop()
+
+	for {
+		select {
+		case <-sighup:
+			log.Println("Received SIGHUP, reloading configuration")
+			hm.reloadConfig()
+			lastMod = configFileModTime()
+		case <-poll.C:
+			if mod := configFileModTime(); !mod.IsZero() && mod.After(lastMod) {
+				log.Println("config.json changed on disk, reloading configuration")
+				hm.reloadConfig()
+				lastMod = mod
+			}
+		}
+	}
+}
+
+// reloadConfig loads and validates a fresh configuration, applying it only on success
+// so that a broken config.json or a failed validation never takes down a running manager.
+func (hm *HeatingManager) reloadConfig() {
+	newCfg, err := loadConfig()
+	if err != nil {
+		log.Printf("Config reload failed, keeping previous configuration: %v", err)
+		return
+	}
+	if err := validateConfig(newCfg); err != nil {
+		log.Printf("Config reload rejected, keeping previous configuration: %v", err)
+		return
+	}
+
+	old := hm.Cfg()
+	for _, change := range diffConfig(old, newCfg) {
+		log.Printf("Config reload: %s", change)
+	}
+
+	hm.setCfg(newCfg)
+	hm.Metrics.SetTemperatureThreshold(newCfg.TemperatureThreshold)
+}
+
+// configFileModTime returns config.json's modification time, or the zero time if it
+// cannot be stat'd.
+func configFileModTime() time.Time {
+	info, err := os.Stat("config.json")
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
@@ -6,7 +6,7 @@ import (
 
 // HeatingManager is the main entry point of the program.
 // It initializes a new HeatingManager instance and
-// starts two goroutines for temperature monitoring and weekly check.
+// starts goroutines for temperature monitoring, weekly check and the admin server.
 // The program then enters an infinite loop, waiting for events.
 func main() {
 	// Initialize a new HeatingManager instance
@@ -21,6 +21,12 @@ func main() {
 	// Start weekly check in a separate goroutine
 	go manager.StartWeeklyCheck()
 
+	// Start the admin/metrics HTTP server in a separate goroutine
+	go manager.StartAdminServer()
+
+	// Watch config.json and SIGHUP for configuration hot-reloads
+	go manager.StartConfigWatcher()
+
 	// Wait for events in an infinite loop
 	select {}
 }
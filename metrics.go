@@ -0,0 +1,206 @@
+// metrics.go
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// defaultLatencyBuckets are the histogram buckets (in seconds) used for API request latencies.
+var defaultLatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// latencyHistogram is a cumulative histogram, matching the Prometheus bucket semantics.
+type latencyHistogram struct {
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+// Metrics holds the in-memory counters and gauges exposed via the /metrics endpoint.
+// All access goes through its methods, which are safe for concurrent use.
+type Metrics struct {
+	mu sync.Mutex
+
+	currentWaterTemp     float64
+	temperatureThreshold float64
+	lastCheckTimestamp   time.Time
+	heatPumpOn           bool
+	pvSurplusWatts       float64
+
+	weeklyCheckSuccess  uint64
+	weeklyCheckFailure  uint64
+	tokenRefreshSuccess uint64
+	tokenRefreshFailure uint64
+
+	latencies map[string]*latencyHistogram
+}
+
+// NewMetrics creates an empty Metrics collector.
+func NewMetrics() *Metrics {
+	return &Metrics{latencies: make(map[string]*latencyHistogram)}
+}
+
+// SetCurrentTemperature records the most recently observed water temperature and the time it was taken.
+func (m *Metrics) SetCurrentTemperature(t float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.currentWaterTemp = t
+	m.lastCheckTimestamp = time.Now()
+}
+
+// SetTemperatureThreshold records the currently configured heating threshold.
+func (m *Metrics) SetTemperatureThreshold(t float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.temperatureThreshold = t
+}
+
+// SetHeatPumpOn records whether the heat pump is currently commanded on.
+func (m *Metrics) SetHeatPumpOn(on bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.heatPumpOn = on
+}
+
+// SetPVSurplusWatts records the most recently observed PV surplus used for heating decisions.
+func (m *Metrics) SetPVSurplusWatts(watts float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pvSurplusWatts = watts
+}
+
+// IncWeeklyCheck increments the weekly Legionella check counter for the given outcome.
+func (m *Metrics) IncWeeklyCheck(success bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if success {
+		m.weeklyCheckSuccess++
+	} else {
+		m.weeklyCheckFailure++
+	}
+}
+
+// IncTokenRefresh increments the auth token refresh counter for the given outcome.
+func (m *Metrics) IncTokenRefresh(success bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if success {
+		m.tokenRefreshSuccess++
+	} else {
+		m.tokenRefreshFailure++
+	}
+}
+
+// ObserveRequestLatency records how long an outbound API call to the given endpoint took.
+func (m *Metrics) ObserveRequestLatency(endpoint string, duration time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	h, ok := m.latencies[endpoint]
+	if !ok {
+		h = &latencyHistogram{buckets: defaultLatencyBuckets, counts: make([]uint64, len(defaultLatencyBuckets))}
+		m.latencies[endpoint] = h
+	}
+
+	seconds := duration.Seconds()
+	h.sum += seconds
+	h.count++
+	for i, bucket := range h.buckets {
+		if seconds <= bucket {
+			h.counts[i]++
+		}
+	}
+}
+
+// Snapshot is a point-in-time copy of the gauges exposed through the status API.
+type Snapshot struct {
+	CurrentTemperature   float64
+	TemperatureThreshold float64
+	LastCheck            time.Time
+	HeatPumpOn           bool
+	PVSurplusWatts       float64
+}
+
+// Snapshot returns a copy of the current gauge values.
+func (m *Metrics) Snapshot() Snapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return Snapshot{
+		CurrentTemperature:   m.currentWaterTemp,
+		TemperatureThreshold: m.temperatureThreshold,
+		LastCheck:            m.lastCheckTimestamp,
+		HeatPumpOn:           m.heatPumpOn,
+		PVSurplusWatts:       m.pvSurplusWatts,
+	}
+}
+
+// WritePrometheus writes all metrics in Prometheus text-exposition format.
+func (m *Metrics) WritePrometheus(w io.Writer) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP pv_heating_current_water_temp_celsius Current water temperature as reported by the configured heat pump driver.")
+	fmt.Fprintln(w, "# TYPE pv_heating_current_water_temp_celsius gauge")
+	fmt.Fprintf(w, "pv_heating_current_water_temp_celsius %g\n", m.currentWaterTemp)
+
+	fmt.Fprintln(w, "# HELP pv_heating_temperature_threshold_celsius Configured temperature threshold that triggers heating.")
+	fmt.Fprintln(w, "# TYPE pv_heating_temperature_threshold_celsius gauge")
+	fmt.Fprintf(w, "pv_heating_temperature_threshold_celsius %g\n", m.temperatureThreshold)
+
+	fmt.Fprintln(w, "# HELP pv_heating_last_check_timestamp_seconds Unix timestamp of the last temperature check.")
+	fmt.Fprintln(w, "# TYPE pv_heating_last_check_timestamp_seconds gauge")
+	fmt.Fprintf(w, "pv_heating_last_check_timestamp_seconds %d\n", m.lastCheckTimestamp.Unix())
+
+	fmt.Fprintln(w, "# HELP pv_heating_heat_pump_on Whether the heat pump is currently commanded on (1) or off (0).")
+	fmt.Fprintln(w, "# TYPE pv_heating_heat_pump_on gauge")
+	fmt.Fprintf(w, "pv_heating_heat_pump_on %d\n", boolToInt(m.heatPumpOn))
+
+	fmt.Fprintln(w, "# HELP pv_heating_pv_surplus_watts Most recently observed PV surplus used for heating decisions.")
+	fmt.Fprintln(w, "# TYPE pv_heating_pv_surplus_watts gauge")
+	fmt.Fprintf(w, "pv_heating_pv_surplus_watts %g\n", m.pvSurplusWatts)
+
+	fmt.Fprintln(w, "# HELP pv_heating_weekly_check_total Number of weekly Legionella checks, by result.")
+	fmt.Fprintln(w, "# TYPE pv_heating_weekly_check_total counter")
+	fmt.Fprintf(w, "pv_heating_weekly_check_total{result=\"success\"} %d\n", m.weeklyCheckSuccess)
+	fmt.Fprintf(w, "pv_heating_weekly_check_total{result=\"failure\"} %d\n", m.weeklyCheckFailure)
+
+	fmt.Fprintln(w, "# HELP pv_heating_token_refresh_total Number of auth token refresh attempts, by result.")
+	fmt.Fprintln(w, "# TYPE pv_heating_token_refresh_total counter")
+	fmt.Fprintf(w, "pv_heating_token_refresh_total{result=\"success\"} %d\n", m.tokenRefreshSuccess)
+	fmt.Fprintf(w, "pv_heating_token_refresh_total{result=\"failure\"} %d\n", m.tokenRefreshFailure)
+
+	if len(m.latencies) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "# HELP pv_heating_api_request_duration_seconds Latency of outbound API requests.")
+	fmt.Fprintln(w, "# TYPE pv_heating_api_request_duration_seconds histogram")
+
+	endpoints := make([]string, 0, len(m.latencies))
+	for endpoint := range m.latencies {
+		endpoints = append(endpoints, endpoint)
+	}
+	sort.Strings(endpoints)
+
+	for _, endpoint := range endpoints {
+		h := m.latencies[endpoint]
+		for i, bucket := range h.buckets {
+			fmt.Fprintf(w, "pv_heating_api_request_duration_seconds_bucket{endpoint=%q,le=\"%g\"} %d\n", endpoint, bucket, h.counts[i])
+		}
+		fmt.Fprintf(w, "pv_heating_api_request_duration_seconds_bucket{endpoint=%q,le=\"+Inf\"} %d\n", endpoint, h.count)
+		fmt.Fprintf(w, "pv_heating_api_request_duration_seconds_sum{endpoint=%q} %g\n", endpoint, h.sum)
+		fmt.Fprintf(w, "pv_heating_api_request_duration_seconds_count{endpoint=%q} %d\n", endpoint, h.count)
+	}
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
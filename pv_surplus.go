@@ -0,0 +1,56 @@
+// pv_surplus.go
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// getPVSurplus fetches the current available PV surplus, in watts, from Solar Manager.
+// It is used by the heating control loop to decide whether there is enough excess
+// solar production to opportunistically turn on the heat pump.
+func (hm *HeatingManager) getPVSurplus() (float64, error) {
+	token, err := hm.getAuthToken()
+	if err != nil {
+		return 0, fmt.Errorf("error getting auth token: %v", err)
+	}
+
+	start := time.Now()
+	defer func() {
+		hm.Metrics.ObserveRequestLatency("solar_manager_pv_surplus", time.Since(start))
+	}()
+
+	url := hm.Cfg().PVSurplusURL
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return 0, fmt.Errorf("error creating request: %v", err)
+	}
+
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+
+	client := &http.Client{}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get PV surplus: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("failed to get PV surplus: status code %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Data struct {
+			SurplusWatt float64 `json:"surplusWatt"`
+		} `json:"data"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("failed to unmarshal PV surplus response: %v", err)
+	}
+
+	return result.Data.SurplusWatt, nil
+}
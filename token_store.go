@@ -0,0 +1,123 @@
+// token_store.go
+
+package main
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// TokenData is the set of auth state persisted across process restarts.
+type TokenData struct {
+	AccessToken  string    `json:"accessToken"`
+	RefreshToken string    `json:"refreshToken"`
+	TokenExpiry  time.Time `json:"tokenExpiry"`
+}
+
+// TokenStore persists and retrieves the auth token across process restarts.
+type TokenStore interface {
+	// Load returns the previously persisted token data. It returns an error if no
+	// token has been persisted yet, or the stored data could not be read.
+	Load() (TokenData, error)
+	// Save persists the given token data, overwriting whatever was stored before.
+	Save(TokenData) error
+}
+
+// fileTokenStore is the default TokenStore implementation, next to LastCheckFile.
+// It stores the token data as JSON, encrypted at rest with AES-GCM using a key
+// derived from a config-supplied secret.
+type fileTokenStore struct {
+	path string
+	key  [32]byte
+}
+
+// NewFileTokenStore creates a file-backed TokenStore at path, encrypted with a key
+// derived from secret. An empty secret still works, but provides no real confidentiality.
+func NewFileTokenStore(path, secret string) TokenStore {
+	return &fileTokenStore{path: path, key: sha256.Sum256([]byte(secret))}
+}
+
+// Load reads and decrypts the token cache file.
+func (s *fileTokenStore) Load() (TokenData, error) {
+	var data TokenData
+
+	ciphertext, err := os.ReadFile(s.path)
+	if err != nil {
+		return data, fmt.Errorf("failed to read token cache: %w", err)
+	}
+
+	plaintext, err := s.decrypt(ciphertext)
+	if err != nil {
+		return data, fmt.Errorf("failed to decrypt token cache: %w", err)
+	}
+
+	if err := json.Unmarshal(plaintext, &data); err != nil {
+		return data, fmt.Errorf("failed to parse token cache: %w", err)
+	}
+
+	return data, nil
+}
+
+// Save encrypts and writes the token cache file.
+func (s *fileTokenStore) Save(data TokenData) error {
+	plaintext, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal token cache: %w", err)
+	}
+
+	ciphertext, err := s.encrypt(plaintext)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt token cache: %w", err)
+	}
+
+	if err := os.WriteFile(s.path, ciphertext, 0600); err != nil {
+		return fmt.Errorf("failed to write token cache: %w", err)
+	}
+
+	return nil
+}
+
+func (s *fileTokenStore) newGCM() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(s.key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (s *fileTokenStore) encrypt(plaintext []byte) ([]byte, error) {
+	gcm, err := s.newGCM()
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func (s *fileTokenStore) decrypt(ciphertext []byte) ([]byte, error) {
+	gcm, err := s.newGCM()
+	if err != nil {
+		return nil, err
+	}
+
+	nonceSize := gcm.NonceSize()
+	if len(ciphertext) < nonceSize {
+		return nil, errors.New("token cache is too short to contain a nonce")
+	}
+
+	nonce, body := ciphertext[:nonceSize], ciphertext[nonceSize:]
+	return gcm.Open(nil, nonce, body, nil)
+}
@@ -0,0 +1,72 @@
+// token_store_test.go
+
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileTokenStoreRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokenCache.json")
+	store := NewFileTokenStore(path, "super-secret-key")
+
+	want := TokenData{
+		AccessToken:  "access-123",
+		RefreshToken: "refresh-456",
+		TokenExpiry:  time.Now().Add(time.Hour).Truncate(time.Second),
+	}
+
+	if err := store.Save(want); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	got, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+
+	if got.AccessToken != want.AccessToken || got.RefreshToken != want.RefreshToken || !got.TokenExpiry.Equal(want.TokenExpiry) {
+		t.Fatalf("round-tripped token data = %+v, want %+v", got, want)
+	}
+}
+
+func TestFileTokenStoreWrongSecretFailsToDecrypt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokenCache.json")
+	store := NewFileTokenStore(path, "correct-secret")
+
+	if err := store.Save(TokenData{AccessToken: "access-123"}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	other := NewFileTokenStore(path, "wrong-secret")
+	if _, err := other.Load(); err == nil {
+		t.Fatal("expected Load with the wrong secret to fail, got nil error")
+	}
+}
+
+func TestTokenNeedsRefresh(t *testing.T) {
+	tests := []struct {
+		name      string
+		expiry    time.Time
+		threshold int
+		want      bool
+	}{
+		{"already expired", time.Now().Add(-time.Minute), 5, true},
+		{"within threshold", time.Now().Add(2 * time.Minute), 5, true},
+		{"valid and far from expiry", time.Now().Add(time.Hour), 5, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hm := &HeatingManager{
+				cfg:         Config{TokenRefreshThreshold: tt.threshold},
+				TokenExpiry: tt.expiry,
+			}
+			if got := hm.tokenNeedsRefresh(); got != tt.want {
+				t.Errorf("tokenNeedsRefresh() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}